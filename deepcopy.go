@@ -3,10 +3,12 @@ package deepcopy
 import (
 	"fmt"
 	. "reflect"
+	"strings"
+	"sync"
 	"unsafe"
 )
 
-type copier func(interface{}, map[uintptr]interface{}) (interface{}, error)
+type copier func(interface{}, map[uintptr]interface{}, *settings) (interface{}, error)
 
 var (
 	copiers   map[Kind]copier
@@ -15,12 +17,15 @@ var (
 
 func init() {
 	copiers = map[Kind]copier{
-		Array:  _array,
-		Map:    _map,
-		Ptr:    _pointer,
-		Slice:  _slice,
-		Struct: _struct,
-		String: _string,
+		Array:         _array,
+		Map:           _map,
+		Ptr:           _pointer,
+		Slice:         _slice,
+		Struct:        _struct,
+		String:        _string,
+		Chan:          _chan,
+		Func:          _func,
+		UnsafePointer: _unsafe_pointer,
 	}
 	primitive = map[Kind]struct{}{
 		Bool:       {},
@@ -42,6 +47,389 @@ func init() {
 	}
 }
 
+// CopierFunc is a custom copy function for a specific type, registered
+// against a Config. It receives the source value and returns the copy,
+// short-circuiting the reflection-based walk entirely. This is the escape
+// hatch for types where field-by-field reflection is wrong or dangerous,
+// e.g. time.Time, sync.Mutex, *big.Int, or net.IP.
+type CopierFunc func(interface{}) (interface{}, error)
+
+// Config holds per-type copiers that override the default reflection-based
+// deep copy. A *Config is safe for concurrent reads, so the same Config can
+// be shared across goroutines performing copies; Register should typically
+// be called during setup, before the Config is used concurrently.
+type Config struct {
+	mu      sync.RWMutex
+	copiers map[Type]CopierFunc
+}
+
+// NewConfig returns an empty Config ready for registering type copiers.
+func NewConfig() *Config {
+	return &Config{copiers: make(map[Type]CopierFunc)}
+}
+
+// Register associates fn with t. Anything and StructPointerCopy calls made
+// through this Config will use fn to copy any value of type t instead of
+// walking it with reflection.
+func (c *Config) Register(t Type, fn CopierFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.copiers[t] = fn
+}
+
+func (c *Config) lookup(t Type) (CopierFunc, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	fn, ok := c.copiers[t]
+	return fn, ok
+}
+
+// Anything makes a deep copy of x, using any copiers registered on c for
+// types that have one and falling back to reflection-based copying
+// otherwise. See the package-level Anything for the general copying rules.
+func (c *Config) Anything(x interface{}) (interface{}, error) {
+	ptrs := make(map[uintptr]interface{})
+	return _anything(x, ptrs, &settings{config: c})
+}
+
+// StructPointerCopy copies x to y, using any copiers registered on c. See
+// the package-level StructPointerCopy for the general rules.
+func (c *Config) StructPointerCopy(x, y interface{}) error {
+	xv, yv := ValueOf(x), ValueOf(y)
+	if xv.Kind() != Ptr || yv.Kind() != Ptr {
+		return fmt.Errorf("must pass value with kind of Ptr; got %v, %v", xv.Kind(), yv.Kind())
+	}
+	if xv.IsNil() || yv.IsNil() {
+		return fmt.Errorf("must pass not nil value; got %v, %v", x, y)
+	}
+	if xv.Type() != yv.Type() {
+		return fmt.Errorf("must pass same type value; got %v %v", x, y)
+	}
+	if xv.Elem().Kind() != Struct || yv.Elem().Kind() != Struct {
+		return fmt.Errorf("must pass struct kind value; got %v %v", x, y)
+	}
+	if fn, ok := c.lookup(xv.Elem().Type()); ok {
+		item, err := fn(xv.Elem().Interface())
+		if err != nil {
+			return err
+		}
+		yv.Elem().Set(ValueOf(item))
+		return nil
+	}
+	ptrs := make(map[uintptr]interface{})
+	ptrs[xv.Pointer()] = y
+	return _struct_pointer(x, y, ptrs, &settings{config: c})
+}
+
+// DeepCopier is implemented by types that know how to make a deep copy of
+// themselves. When a value (or its pointer) implements DeepCopier, Anything
+// and friends call DeepCopy instead of walking the value with reflection.
+// This lets callers opt hot types out of reflection entirely. A
+// pointer-receiver DeepCopy is found on struct values and on pointers; a
+// value of any other kind whose DeepCopy has a pointer receiver is copied
+// with reflection unless it is reached through a pointer.
+//
+// Code generated by controller-tools emits a typed `DeepCopy() *T` method
+// instead, which can't satisfy this interface directly (Go has no covariant
+// return types): asDeepCopier detects that shape by reflection and adapts
+// it, so generated types are picked up too without needing this interface
+// spelled out by hand.
+type DeepCopier interface {
+	DeepCopy() interface{}
+}
+
+// DeepCopyInto is implemented by types that can deep copy themselves into an
+// existing destination, avoiding the extra allocation DeepCopy requires.
+// dst is untyped so that a single interface can be shared across all types;
+// as with DeepCopier, asDeepCopyInto also detects and adapts the typed
+// `DeepCopyInto(out *T)` methods emitted by controller-tools style code
+// generators, which can't satisfy this interface as declared.
+type DeepCopyInto interface {
+	DeepCopyInto(dst interface{})
+}
+
+var deepCopierType = TypeOf((*DeepCopier)(nil)).Elem()
+
+// asDeepCopier reports whether x (or an addressable copy of it, to pick up
+// pointer-receiver implementations) implements DeepCopier, either directly
+// or via a controller-tools style `func (in *T) DeepCopy() *T` method. It
+// only probes struct values this way; see asPointerDeepCopier for the
+// equivalent check on values already behind a pointer.
+//
+// Go has no covariant return types, so a generated `DeepCopy() *T` method
+// never satisfies the interface{}-typed DeepCopier interface on its own;
+// typedDeepCopier below adapts it so those generated methods are still
+// picked up.
+func asDeepCopier(x interface{}) (DeepCopier, bool) {
+	if dc, ok := x.(DeepCopier); ok {
+		return dc, true
+	}
+	v := ValueOf(x)
+	if !v.IsValid() || v.Kind() != Struct {
+		return nil, false
+	}
+	pv := New(v.Type())
+	pv.Elem().Set(v)
+	if dc, ok := pv.Interface().(DeepCopier); ok {
+		return dc, true
+	}
+	// x came in as a Struct value, so the typed method's *T result has to be
+	// dereferenced back to a T before it can stand in for x -- unlike
+	// asPointerDeepCopier, where x is already a pointer and *T is exactly
+	// what the caller wants back.
+	if dc, ok := asTypedDeepCopier(pv, true); ok {
+		return dc, true
+	}
+	return nil, false
+}
+
+// asPointerDeepCopier reports whether a pointer Value v implements
+// DeepCopier, directly or via the typed controller-tools style
+// `DeepCopy() *T` method. _anything's own asDeepCopier check bails out on
+// Ptr kind (it's reached before _pointer/_struct_pointer even see x), so
+// those two call this separately -- and after their DeepCopyInto check, so
+// a type offering both hooks still prefers the allocation-avoiding one.
+func asPointerDeepCopier(v Value) (DeepCopier, bool) {
+	if dc, ok := v.Interface().(DeepCopier); ok {
+		return dc, true
+	}
+	return asTypedDeepCopier(v, false)
+}
+
+// asTypedDeepCopier reports whether pv, a pointer Value, has a
+// controller-tools style `DeepCopy() *T` method, where T is pv's pointee
+// type, and adapts it to DeepCopier. dereference controls whether the
+// adapted DeepCopy unwraps the method's *T result back to a T: asDeepCopier
+// needs that since it's standing in for a plain Struct value, while
+// asPointerDeepCopier wants the *T result as-is since x was already a
+// pointer.
+func asTypedDeepCopier(pv Value, dereference bool) (DeepCopier, bool) {
+	m := pv.MethodByName("DeepCopy")
+	if !m.IsValid() {
+		return nil, false
+	}
+	mt := m.Type()
+	if mt.NumIn() != 0 || mt.NumOut() != 1 || mt.Out(0) != pv.Type() {
+		return nil, false
+	}
+	return typedDeepCopier{method: m, dereference: dereference}, true
+}
+
+// typedDeepCopier adapts a `func() *T` method value to DeepCopier.
+type typedDeepCopier struct {
+	method      Value
+	dereference bool
+}
+
+func (t typedDeepCopier) DeepCopy() interface{} {
+	result := t.method.Call(nil)[0]
+	if t.dereference {
+		return result.Elem().Interface()
+	}
+	return result.Interface()
+}
+
+// typeHasDeepCopyHook reports, from t alone, whether values of t may be
+// diverted to the DeepCopier hook instead of being copied field by field.
+// It reports true for every interface type, since the dynamic value held
+// there may have a hook, and for a non-struct type whose DeepCopy has a
+// pointer receiver, even though asDeepCopier does not pick that one up.
+// Unlike asDeepCopier this needs no value, so it can run once per type when
+// a structPlan is built, to decide whether a primitive-kind field can safely
+// take the memcpy fast path or must keep going through _anything on every
+// copy.
+func typeHasDeepCopyHook(t Type) bool {
+	if t.Kind() == Interface {
+		return true
+	}
+	if t.Implements(deepCopierType) {
+		return true
+	}
+	pt := PtrTo(t)
+	if pt.Implements(deepCopierType) {
+		return true
+	}
+	if m, ok := pt.MethodByName("DeepCopy"); ok {
+		mt := m.Type
+		if mt.NumIn() == 1 && mt.NumOut() == 1 && mt.Out(0) == pt {
+			return true
+		}
+	}
+	return false
+}
+
+// asDeepCopyInto reports whether x implements DeepCopyInto, either directly
+// or via a controller-tools style `func (in *T) DeepCopyInto(out *T)`
+// method, and returns it as a plain func for the caller to invoke. The same
+// covariant/contravariant limitation that applies to DeepCopier applies
+// here: DeepCopyInto's dst is typed interface{} so the bespoke interface can
+// be declared at all, but a generated `DeepCopyInto(out *T)` method doesn't
+// satisfy it either, so it needs the same reflection-based detection.
+func asDeepCopyInto(x interface{}) (func(dst interface{}), bool) {
+	if dci, ok := x.(DeepCopyInto); ok {
+		return dci.DeepCopyInto, true
+	}
+	v := ValueOf(x)
+	if !v.IsValid() {
+		return nil, false
+	}
+	m := v.MethodByName("DeepCopyInto")
+	if !m.IsValid() {
+		return nil, false
+	}
+	mt := m.Type()
+	if mt.NumIn() != 1 || mt.NumOut() != 0 || mt.In(0) != v.Type() {
+		return nil, false
+	}
+	return func(dst interface{}) {
+		m.Call([]Value{ValueOf(dst)})
+	}, true
+}
+
+// ChannelStrategy controls how a copy handles channel values, which
+// reflection has no way to deep copy.
+type ChannelStrategy int
+
+const (
+	// ChannelUnsupported fails the copy with an error. This is the default.
+	ChannelUnsupported ChannelStrategy = iota
+	// ChannelShallow reuses the source channel in the copy.
+	ChannelShallow
+	// ChannelNewEmpty creates a fresh channel with the same type, direction
+	// and buffer size as the source, without any of its buffered values.
+	ChannelNewEmpty
+	// ChannelDrainAndRefill creates a fresh channel like ChannelNewEmpty,
+	// then non-blocking-receives everything currently buffered in the
+	// source channel and sends it into the new one.
+	ChannelDrainAndRefill
+)
+
+// FuncStrategy controls how a copy handles func values, which reflection
+// has no way to deep copy.
+type FuncStrategy int
+
+const (
+	// FuncUnsupported fails the copy with an error. This is the default.
+	FuncUnsupported FuncStrategy = iota
+	// FuncShallow reuses the source func value in the copy.
+	FuncShallow
+	// FuncNilOut leaves the destination func field/value nil.
+	FuncNilOut
+)
+
+// UnsafePointerStrategy controls how a copy handles unsafe.Pointer values,
+// which reflection has no way to deep copy.
+type UnsafePointerStrategy int
+
+const (
+	// UnsafePointerUnsupported fails the copy with an error. This is the
+	// default.
+	UnsafePointerUnsupported UnsafePointerStrategy = iota
+	// UnsafePointerShallow reuses the source pointer in the copy.
+	UnsafePointerShallow
+	// UnsafePointerNilOut leaves the destination pointer nil.
+	UnsafePointerNilOut
+)
+
+// settings carries the Config and DeepCopyOption-derived flags through a
+// single copy, so the reflection walker doesn't need a growing list of
+// parameters every time a new knob is added.
+type settings struct {
+	config                *Config
+	copyUnexported        bool
+	channelStrategy       ChannelStrategy
+	funcStrategy          FuncStrategy
+	unsafePointerStrategy UnsafePointerStrategy
+	disallowCycles        bool
+	blocklist             map[Type]struct{}
+}
+
+// DeepCopyOption configures a copy made through AnythingWithOptions.
+type DeepCopyOption func(*settings)
+
+// WithConfig attaches c to the copy, so types with a copier registered on c
+// use it instead of reflection, the same as Config.Anything/
+// Config.StructPointerCopy -- but composable with the other DeepCopyOptions,
+// which those Config methods have no way to accept.
+func WithConfig(c *Config) DeepCopyOption {
+	return func(s *settings) { s.config = c }
+}
+
+// WithCopyUnexported makes AnythingWithOptions copy unexported struct fields
+// instead of silently leaving them zero-valued in the destination. This
+// uses unsafe to read and write fields whose PkgPath is non-empty.
+func WithCopyUnexported() DeepCopyOption {
+	return func(s *settings) { s.copyUnexported = true }
+}
+
+// WithChannelStrategy sets how channel values are copied. See
+// ChannelStrategy for the available strategies.
+func WithChannelStrategy(strategy ChannelStrategy) DeepCopyOption {
+	return func(s *settings) { s.channelStrategy = strategy }
+}
+
+// WithFuncStrategy sets how func values are copied. See FuncStrategy for
+// the available strategies.
+func WithFuncStrategy(strategy FuncStrategy) DeepCopyOption {
+	return func(s *settings) { s.funcStrategy = strategy }
+}
+
+// WithUnsafePointerStrategy sets how unsafe.Pointer values are copied. See
+// UnsafePointerStrategy for the available strategies.
+func WithUnsafePointerStrategy(strategy UnsafePointerStrategy) DeepCopyOption {
+	return func(s *settings) { s.unsafePointerStrategy = strategy }
+}
+
+// WithShallowChannels makes AnythingWithOptions copy channels by reference
+// instead of failing with an "unsupported kind" error. It is shorthand for
+// WithChannelStrategy(ChannelShallow).
+func WithShallowChannels() DeepCopyOption {
+	return WithChannelStrategy(ChannelShallow)
+}
+
+// WithShallowFuncs makes AnythingWithOptions copy funcs by reference instead
+// of failing with an "unsupported kind" error. It is shorthand for
+// WithFuncStrategy(FuncShallow).
+func WithShallowFuncs() DeepCopyOption {
+	return WithFuncStrategy(FuncShallow)
+}
+
+// WithDisallowCycles makes AnythingWithOptions return an error when it
+// encounters a pointer it has already visited, instead of reusing the copy
+// already made for it.
+func WithDisallowCycles() DeepCopyOption {
+	return func(s *settings) { s.disallowCycles = true }
+}
+
+// WithTypeBlocklist makes AnythingWithOptions shallow-copy values of the
+// given types instead of recursing into them. This is meant for types like
+// sync.Mutex that field-by-field reflection would corrupt.
+func WithTypeBlocklist(types ...Type) DeepCopyOption {
+	return func(s *settings) {
+		if s.blocklist == nil {
+			s.blocklist = make(map[Type]struct{}, len(types))
+		}
+		for _, t := range types {
+			s.blocklist[t] = struct{}{}
+		}
+	}
+}
+
+// AnythingWithOptions makes a deep copy of x like Anything, but lets callers
+// adjust the default behavior with DeepCopyOptions, e.g. to copy unexported
+// fields or to allow channels and funcs to be copied by reference.
+func AnythingWithOptions(x interface{}, opts ...DeepCopyOption) (interface{}, error) {
+	s := &settings{}
+	for _, opt := range opts {
+		opt(s)
+	}
+	ptrs := make(map[uintptr]interface{})
+	return _anything(x, ptrs, s)
+}
+
 // MustAnything does a deep copy and panics on any errors.
 func MustAnything(x interface{}) interface{} {
 	dc, err := Anything(x)
@@ -51,17 +439,19 @@ func MustAnything(x interface{}) interface{} {
 	return dc
 }
 
-// Anything makes a deep copy of whatever gets passed in. It handles pretty much all known Go types
-// (with the exception of channels, unsafe pointers, and functions). Note that this is a truly deep
-// copy that will work it's way all the way to the leaves of the types--any pointer will be copied,
-// any values in any slice or map will be deep copied, etc.
+// Anything makes a deep copy of whatever gets passed in. It handles pretty much all known Go types.
+// Channels, funcs, and unsafe.Pointer values have no well-defined deep copy, so by default Anything
+// errors out on them; use AnythingWithOptions with a channel/func/unsafe.Pointer strategy to allow
+// them through instead. Note that this is a truly deep copy that will work it's way all the way to
+// the leaves of the types--any pointer will be copied, any values in any slice or map will be deep
+// copied, etc.
 // Note: in order to avoid an infinite loop, we keep track of any pointers that we've run across.
 // If we run into that pointer again, we don't make another deep copy of it; we just replace it with
 // the copy we've already made. This also ensures that the cloned result is functionally equivalent
 // to the original value.
 func Anything(x interface{}) (interface{}, error) {
 	ptrs := make(map[uintptr]interface{})
-	return _anything(x, ptrs)
+	return _anything(x, ptrs, &settings{})
 }
 
 // StructPointerCopy copies x to y.
@@ -82,7 +472,7 @@ func StructPointerCopy(x, y interface{}) error {
 	}
 	ptrs := make(map[uintptr]interface{})
 	ptrs[xv.Pointer()] = y
-	return _struct_pointer(x, y, ptrs)
+	return _struct_pointer(x, y, ptrs, &settings{})
 }
 
 func MustString(s string) string {
@@ -91,24 +481,211 @@ func MustString(s string) string {
 	return *ns
 }
 
-func _anything(x interface{}, ptrs map[uintptr]interface{}) (interface{}, error) {
+// unsafeIndirect returns an addressable, settable Value for v, bypassing
+// the read-only flag reflect sets on values reached through unexported
+// struct fields. v must be addressable.
+func unsafeIndirect(v Value) Value {
+	return NewAt(v.Type(), unsafe.Pointer(v.UnsafeAddr())).Elem()
+}
+
+// fieldPolicy is the effective `deepcopy` struct tag policy for a field.
+type fieldPolicy int
+
+const (
+	// policyDefault copies the field the normal way: recursively, unless
+	// its kind is primitive.
+	policyDefault fieldPolicy = iota
+	// policySkip leaves the field zero-valued in the destination. Set via
+	// the `deepcopy:"-"` or `deepcopy:"nocopy"` tag.
+	policySkip
+	// policyShallow assigns the field by value without recursing into it.
+	// Set via the `deepcopy:"shallow"` tag; intended for fields such as
+	// sync.Mutex or cache handles that must not be deep copied.
+	policyShallow
+)
+
+// parseFieldPolicy reads the `deepcopy` struct tag off f, if any.
+func parseFieldPolicy(f StructField) fieldPolicy {
+	tag, ok := f.Tag.Lookup("deepcopy")
+	if !ok {
+		return policyDefault
+	}
+	for _, tok := range strings.Split(tag, ",") {
+		switch strings.TrimSpace(tok) {
+		case "-", "nocopy":
+			return policySkip
+		case "shallow":
+			return policyShallow
+		}
+	}
+	return policyDefault
+}
+
+// fieldPlan describes how to copy a single struct field, worked out once
+// per struct type so that repeated copies don't pay for NumField/Field(i),
+// kind lookups, and struct tag parsing on every call.
+type fieldPlan struct {
+	index    int
+	name     string
+	ftype    Type
+	exported bool
+	// primitive is true when the field's static type can just be Set
+	// instead of recursed through _anything -- but only once hasHook rules
+	// out a DeepCopier hook on that exact type; a Config
+	// copier is checked at copy time since a Config is chosen per call.
+	primitive bool
+	// hasHook is typeHasDeepCopyHook for the field's static type: true when
+	// it may have a DeepCopier hook (directly or via the typed
+	// controller-tools shape). DeepCopyInto is not checked here; it is only
+	// used on pointers, by _pointer and _struct_pointer. Besides gating the primitive fast path, it's precomputed for every
+	// field kind so _struct/_struct_pointer can skip _anything's redundant
+	// per-call asDeepCopier probe on non-primitive fields too, once the
+	// field's static type is already known to have no hook.
+	hasHook bool
+	policy  fieldPolicy
+}
+
+// structPlan is the precompiled copy plan for a struct type.
+type structPlan struct {
+	fields []fieldPlan
+}
+
+// planCache memoizes structPlans by reflect.Type so that _struct and
+// _struct_pointer only walk a type's fields with reflection once, no matter
+// how many values of that type get copied.
+var planCache sync.Map // map[Type]*structPlan
+
+func planFor(t Type) *structPlan {
+	if p, ok := planCache.Load(t); ok {
+		return p.(*structPlan)
+	}
+	fields := make([]fieldPlan, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		_, isPrimitive := primitive[f.Type.Kind()]
+		fields[i] = fieldPlan{
+			index:     i,
+			name:      f.Name,
+			ftype:     f.Type,
+			exported:  f.PkgPath == "",
+			primitive: isPrimitive,
+			hasHook:   typeHasDeepCopyHook(f.Type),
+			policy:    parseFieldPolicy(f),
+		}
+	}
+	p := &structPlan{fields: fields}
+	actual, _ := planCache.LoadOrStore(t, p)
+	return actual.(*structPlan)
+}
+
+func _anything(x interface{}, ptrs map[uintptr]interface{}, s *settings) (interface{}, error) {
 	v := ValueOf(x)
 	if !v.IsValid() {
 		return x, nil
 	}
+	if _, blocked := s.blocklist[v.Type()]; blocked {
+		return x, nil
+	}
+	if fn, ok := s.config.lookup(v.Type()); ok {
+		return fn(x)
+	}
+	if dc, ok := asDeepCopier(x); ok {
+		return dc.DeepCopy(), nil
+	}
+	return _anythingDispatch(x, v, ptrs, s)
+}
+
+// _anythingNoHookProbe is _anything without the asDeepCopier reflection
+// probe, for callers that already know (via a precomputed fieldPlan or a
+// once-per-call typeHasDeepCopyHook check on an element type) that x's
+// static type has no DeepCopier hook. Skipping the probe avoids
+// the New/Set allocation asDeepCopier needs to test a struct value, which
+// otherwise gets paid on every element of a slice/array/map or every
+// non-primitive struct field, even though the plan already ruled it out.
+func _anythingNoHookProbe(x interface{}, ptrs map[uintptr]interface{}, s *settings) (interface{}, error) {
+	v := ValueOf(x)
+	if !v.IsValid() {
+		return x, nil
+	}
+	if _, blocked := s.blocklist[v.Type()]; blocked {
+		return x, nil
+	}
+	if fn, ok := s.config.lookup(v.Type()); ok {
+		return fn(x)
+	}
+	return _anythingDispatch(x, v, ptrs, s)
+}
+
+func _anythingDispatch(x interface{}, v Value, ptrs map[uintptr]interface{}, s *settings) (interface{}, error) {
 	if _, ok := primitive[v.Kind()]; ok {
 		return x, nil
 	}
 	if c, ok := copiers[v.Kind()]; ok {
-		return c(x, ptrs)
+		return c(x, ptrs, s)
 	}
 	t := TypeOf(x)
 	return nil, fmt.Errorf("unable to make a deep copy of %v (type: %v) - kind %v is not supported", x, t, v.Kind())
 }
 
-func _string(x interface{}, ptrs map[uintptr]interface{}) (interface{}, error) {
-	if s, ok := x.(string); ok {
-		buf := []byte(s)
+func _chan(x interface{}, ptrs map[uintptr]interface{}, s *settings) (interface{}, error) {
+	v := ValueOf(x)
+	switch s.channelStrategy {
+	case ChannelShallow:
+		return x, nil
+	case ChannelNewEmpty, ChannelDrainAndRefill:
+		t := v.Type()
+		// MakeChan panics on directional channel types, so build a
+		// bidirectional channel and convert it back to t's direction.
+		makeType := t
+		if t.ChanDir() != BothDir {
+			makeType = ChanOf(BothDir, t.Elem())
+		}
+		dc := MakeChan(makeType, v.Cap())
+		if s.channelStrategy == ChannelDrainAndRefill && t.ChanDir() != SendDir {
+			for {
+				item, ok := v.TryRecv()
+				if !ok {
+					break
+				}
+				dc.Send(item)
+			}
+		}
+		if makeType != t {
+			return dc.Convert(t).Interface(), nil
+		}
+		return dc.Interface(), nil
+	default:
+		return nil, fmt.Errorf("unable to make a deep copy of a channel of type %v: no channel strategy configured", v.Type())
+	}
+}
+
+func _func(x interface{}, ptrs map[uintptr]interface{}, s *settings) (interface{}, error) {
+	v := ValueOf(x)
+	switch s.funcStrategy {
+	case FuncShallow:
+		return x, nil
+	case FuncNilOut:
+		return Zero(v.Type()).Interface(), nil
+	default:
+		return nil, fmt.Errorf("unable to make a deep copy of a func of type %v: no func strategy configured", v.Type())
+	}
+}
+
+func _unsafe_pointer(x interface{}, ptrs map[uintptr]interface{}, s *settings) (interface{}, error) {
+	v := ValueOf(x)
+	switch s.unsafePointerStrategy {
+	case UnsafePointerShallow:
+		return x, nil
+	case UnsafePointerNilOut:
+		return Zero(v.Type()).Interface(), nil
+	default:
+		return nil, fmt.Errorf("unable to make a deep copy of an unsafe.Pointer: no unsafe.Pointer strategy configured")
+	}
+}
+
+func _string(x interface{}, ptrs map[uintptr]interface{}, s *settings) (interface{}, error) {
+	if str, ok := x.(string); ok {
+		buf := []byte(str)
 		ns := (*string)(unsafe.Pointer(&buf))
 		return *ns, nil
 	} else {
@@ -116,7 +693,25 @@ func _string(x interface{}, ptrs map[uintptr]interface{}) (interface{}, error) {
 	}
 }
 
-func _slice(x interface{}, ptrs map[uintptr]interface{}) (interface{}, error) {
+// canFastCopyElems reports whether a Slice/Array of elemType can be copied
+// with a single reflect.Copy instead of a per-element _anything call. That's
+// only safe when elemType's static kind is primitive and nothing would
+// otherwise intercept it: no DeepCopier hook and no Config
+// copier registered for elemType on this call's settings.
+func canFastCopyElems(elemType Type, s *settings) bool {
+	if _, isPrimitive := primitive[elemType.Kind()]; !isPrimitive {
+		return false
+	}
+	if typeHasDeepCopyHook(elemType) {
+		return false
+	}
+	if _, ok := s.config.lookup(elemType); ok {
+		return false
+	}
+	return true
+}
+
+func _slice(x interface{}, ptrs map[uintptr]interface{}, s *settings) (interface{}, error) {
 	v := ValueOf(x)
 	if v.Kind() != Slice {
 		return nil, fmt.Errorf("must pass a value with kind of Slice; got %v", v.Kind())
@@ -128,8 +723,19 @@ func _slice(x interface{}, ptrs map[uintptr]interface{}) (interface{}, error) {
 	// Create a new slice and, for each item in the slice, make a deep copy of it.
 	size := v.Len()
 	dc := MakeSlice(t, size, size)
+	if canFastCopyElems(t.Elem(), s) {
+		Copy(dc, v)
+		return dc.Interface(), nil
+	}
+	elemHasHook := typeHasDeepCopyHook(t.Elem())
 	for i := 0; i < size; i++ {
-		item, err := _anything(v.Index(i).Interface(), ptrs)
+		var item interface{}
+		var err error
+		if elemHasHook {
+			item, err = _anything(v.Index(i).Interface(), ptrs, s)
+		} else {
+			item, err = _anythingNoHookProbe(v.Index(i).Interface(), ptrs, s)
+		}
 		if err != nil {
 			return nil, fmt.Errorf("failed to clone slice item at index %v: %v", i, err)
 		}
@@ -141,7 +747,7 @@ func _slice(x interface{}, ptrs map[uintptr]interface{}) (interface{}, error) {
 	return dc.Interface(), nil
 }
 
-func _map(x interface{}, ptrs map[uintptr]interface{}) (interface{}, error) {
+func _map(x interface{}, ptrs map[uintptr]interface{}, s *settings) (interface{}, error) {
 	v := ValueOf(x)
 	if v.Kind() != Map {
 		return nil, fmt.Errorf("must pass a value with kind of Map; got %v", v.Kind())
@@ -151,13 +757,25 @@ func _map(x interface{}, ptrs map[uintptr]interface{}) (interface{}, error) {
 		return Zero(t).Interface(), nil
 	}
 	dc := MakeMapWithSize(t, v.Len())
+	valueHasHook := typeHasDeepCopyHook(t.Elem())
+	keyHasHook := typeHasDeepCopyHook(t.Key())
 	iter := v.MapRange()
 	for iter.Next() {
-		item, err := _anything(iter.Value().Interface(), ptrs)
+		var item, k interface{}
+		var err error
+		if valueHasHook {
+			item, err = _anything(iter.Value().Interface(), ptrs, s)
+		} else {
+			item, err = _anythingNoHookProbe(iter.Value().Interface(), ptrs, s)
+		}
 		if err != nil {
 			return nil, fmt.Errorf("failed to clone map item %v: %v", iter.Key().Interface(), err)
 		}
-		k, err := _anything(iter.Key().Interface(), ptrs)
+		if keyHasHook {
+			k, err = _anything(iter.Key().Interface(), ptrs, s)
+		} else {
+			k, err = _anythingNoHookProbe(iter.Key().Interface(), ptrs, s)
+		}
 		if err != nil {
 			return nil, fmt.Errorf("failed to clone the map key %v: %v", k, err)
 		}
@@ -166,7 +784,7 @@ func _map(x interface{}, ptrs map[uintptr]interface{}) (interface{}, error) {
 	return dc.Interface(), nil
 }
 
-func _pointer(x interface{}, ptrs map[uintptr]interface{}) (interface{}, error) {
+func _pointer(x interface{}, ptrs map[uintptr]interface{}, s *settings) (interface{}, error) {
 	v := ValueOf(x)
 	if v.Kind() != Ptr {
 		return nil, fmt.Errorf("must pass a value with kind of Ptr; got %v", v.Kind())
@@ -179,26 +797,59 @@ func _pointer(x interface{}, ptrs map[uintptr]interface{}) (interface{}, error)
 
 	addr := v.Pointer()
 	if dc, ok := ptrs[addr]; ok {
+		if s.disallowCycles {
+			return nil, fmt.Errorf("cycle detected while copying %v", t)
+		}
 		return dc, nil
 	}
+	if dci, ok := asDeepCopyInto(x); ok {
+		dc := New(t.Elem())
+		ptrs[addr] = dc.Interface()
+		dci(dc.Interface())
+		return dc.Interface(), nil
+	}
+	// _anything's own asDeepCopier(x) check already tried and failed the
+	// plain interface{}-typed DeepCopier here, since that part of the check
+	// doesn't care about x's kind. But it can't see a typed `DeepCopy() *T`
+	// method, since asDeepCopier only probes that shape on struct values;
+	// asPointerDeepCopier covers the gap for x already being a pointer.
+	if dc, ok := asPointerDeepCopier(v); ok {
+		result := dc.DeepCopy()
+		ptrs[addr] = result
+		return result, nil
+	}
+	// A blocklisted or Config-registered pointee type goes through _anything.
+	_, blocked := s.blocklist[t.Elem()]
+	if _, registered := s.config.lookup(t.Elem()); blocked || registered {
+		dc := New(t.Elem())
+		ptrs[addr] = dc.Interface()
+		item, err := _anything(v.Elem().Interface(), ptrs, s)
+		if err != nil {
+			return nil, fmt.Errorf("failed to copy the value under the pointer %v: %v", v, err)
+		}
+		if iv := ValueOf(item); iv.IsValid() {
+			dc.Elem().Set(iv)
+		}
+		return dc.Interface(), nil
+	}
 	dc := New(t.Elem())
 	ptrs[addr] = dc.Interface()
 
 	switch v.Elem().Kind() {
 	case Struct:
-		err := _struct_pointer(x, dc.Interface(), ptrs)
+		err := _struct_pointer(x, dc.Interface(), ptrs, s)
 		if err != nil {
 			return nil, err
 		}
 		return dc.Interface(), nil
 	case Array:
-		err := _array_pointer(x, dc.Interface(), ptrs)
+		err := _array_pointer(x, dc.Interface(), ptrs, s)
 		if err != nil {
 			return nil, err
 		}
 		return dc.Interface(), nil
 	default:
-		item, err := _anything(v.Elem().Interface(), ptrs)
+		item, err := _anything(v.Elem().Interface(), ptrs, s)
 		if err != nil {
 			return nil, fmt.Errorf("failed to copy the value under the pointer %v: %v", v, err)
 		}
@@ -210,46 +861,152 @@ func _pointer(x interface{}, ptrs map[uintptr]interface{}) (interface{}, error)
 	}
 }
 
-func _struct_pointer(x, y interface{}, ptrs map[uintptr]interface{}) error {
+func _struct_pointer(x, y interface{}, ptrs map[uintptr]interface{}, s *settings) error {
+	if dci, ok := asDeepCopyInto(x); ok {
+		dci(y)
+		return nil
+	}
+	// Unlike _struct/_pointer, _struct_pointer is also reachable directly
+	// from StructPointerCopy without going through _anything first, so a
+	// type implementing only the typed DeepCopy() *T hook (no DeepCopyInto)
+	// needs its own probe here rather than relying on an earlier one.
+	if dc, ok := asPointerDeepCopier(ValueOf(x)); ok {
+		result := dc.DeepCopy()
+		rv := ValueOf(result)
+		if rv.Kind() == Ptr && !rv.IsNil() {
+			ValueOf(y).Elem().Set(rv.Elem())
+			return nil
+		}
+		return fmt.Errorf("DeepCopy for %T returned %v, want a non-nil pointer of the same type", x, result)
+	}
 	v := ValueOf(x).Elem()
 	t := v.Type()
 	dc := ValueOf(y).Elem()
-	for i := 0; i < t.NumField(); i++ {
-		f := t.Field(i)
-		if f.PkgPath != "" {
+	plan := planFor(t)
+	for _, fp := range plan.fields {
+		if fp.policy == policySkip {
+			continue
+		}
+		// An explicit shallow tag applies regardless of WithCopyUnexported:
+		// it's how callers reach fields like an unexported sync.Mutex that
+		// must never be recursed into, which is the common case for fields
+		// this tag targets.
+		if !fp.exported && !s.copyUnexported && fp.policy != policyShallow {
+			continue
+		}
+		fv := v.Field(fp.index)
+		if !fp.exported {
+			fv = unsafeIndirect(fv)
+		}
+		dst := dc.Field(fp.index)
+		if !fp.exported {
+			dst = unsafeIndirect(dst)
+		}
+		if fp.policy == policyShallow {
+			dst.Set(fv)
+			continue
+		}
+		if fp.primitive && !fp.hasHook {
+			if fn, ok := s.config.lookup(fp.ftype); ok {
+				item, err := fn(fv.Interface())
+				if err != nil {
+					return fmt.Errorf("failed to copy the field %v in the struct %#v: %v", fp.name, x, err)
+				}
+				dst.Set(ValueOf(item))
+				continue
+			}
+			dst.Set(fv)
 			continue
 		}
-		item, err := _anything(v.Field(i).Interface(), ptrs)
+		var item interface{}
+		var err error
+		if fp.hasHook {
+			item, err = _anything(fv.Interface(), ptrs, s)
+		} else {
+			item, err = _anythingNoHookProbe(fv.Interface(), ptrs, s)
+		}
 		if err != nil {
-			return fmt.Errorf("failed to copy the field %v in the struct %#v: %v", t.Field(i).Name, x, err)
+			return fmt.Errorf("failed to copy the field %v in the struct %#v: %v", fp.name, x, err)
 		}
-		dc.Field(i).Set(ValueOf(item))
+		dst.Set(ValueOf(item))
 	}
 	return nil
 }
 
-func _struct(x interface{}, ptrs map[uintptr]interface{}) (interface{}, error) {
+func _struct(x interface{}, ptrs map[uintptr]interface{}, s *settings) (interface{}, error) {
 	v := ValueOf(x)
 	if v.Kind() != Struct {
 		return nil, fmt.Errorf("must pass a value with kind of Struct; got %v", v.Kind())
 	}
+	// No asDeepCopier(x) check here: _anything already tried it with this
+	// exact x before dispatching into _struct via the copiers map, and
+	// _anythingNoHookProbe only dispatches here once typeHasDeepCopyHook has
+	// ruled a hook out, so by construction it would be false.
 	t := TypeOf(x)
 	dc := New(t)
-	for i := 0; i < t.NumField(); i++ {
-		f := t.Field(i)
-		if f.PkgPath != "" {
+	plan := planFor(t)
+	// addr is a settable copy of v, built lazily the first time an
+	// unexported field needs reading: v itself came in through an
+	// interface{} and reflect marks that read-only, so unexported fields
+	// can only be reached via unsafeIndirect on an addressable copy.
+	var addr Value
+	for _, fp := range plan.fields {
+		if fp.policy == policySkip {
 			continue
 		}
-		item, err := _anything(v.Field(i).Interface(), ptrs)
+		// An explicit shallow tag applies regardless of WithCopyUnexported:
+		// it's how callers reach fields like an unexported sync.Mutex that
+		// must never be recursed into, which is the common case for fields
+		// this tag targets.
+		if !fp.exported && !s.copyUnexported && fp.policy != policyShallow {
+			continue
+		}
+		var fv Value
+		if !fp.exported {
+			if !addr.IsValid() {
+				addr = New(t).Elem()
+				addr.Set(v)
+			}
+			fv = unsafeIndirect(addr.Field(fp.index))
+		} else {
+			fv = v.Field(fp.index)
+		}
+		dst := dc.Elem().Field(fp.index)
+		if !fp.exported {
+			dst = unsafeIndirect(dst)
+		}
+		if fp.policy == policyShallow {
+			dst.Set(fv)
+			continue
+		}
+		if fp.primitive && !fp.hasHook {
+			if fn, ok := s.config.lookup(fp.ftype); ok {
+				item, err := fn(fv.Interface())
+				if err != nil {
+					return nil, fmt.Errorf("failed to copy the field %v in the struct %#v: %v", fp.name, x, err)
+				}
+				dst.Set(ValueOf(item))
+				continue
+			}
+			dst.Set(fv)
+			continue
+		}
+		var item interface{}
+		var err error
+		if fp.hasHook {
+			item, err = _anything(fv.Interface(), ptrs, s)
+		} else {
+			item, err = _anythingNoHookProbe(fv.Interface(), ptrs, s)
+		}
 		if err != nil {
-			return nil, fmt.Errorf("failed to copy the field %v in the struct %#v: %v", t.Field(i).Name, x, err)
+			return nil, fmt.Errorf("failed to copy the field %v in the struct %#v: %v", fp.name, x, err)
 		}
-		dc.Elem().Field(i).Set(ValueOf(item))
+		dst.Set(ValueOf(item))
 	}
 	return dc.Elem().Interface(), nil
 }
 
-func _array(x interface{}, ptrs map[uintptr]interface{}) (interface{}, error) {
+func _array(x interface{}, ptrs map[uintptr]interface{}, s *settings) (interface{}, error) {
 	v := ValueOf(x)
 	if v.Kind() != Array {
 		return nil, fmt.Errorf("must pass a value with kind of Array; got %v", v.Kind())
@@ -257,8 +1014,19 @@ func _array(x interface{}, ptrs map[uintptr]interface{}) (interface{}, error) {
 	t := TypeOf(x)
 	size := t.Len()
 	dc := New(ArrayOf(size, t.Elem())).Elem()
+	if canFastCopyElems(t.Elem(), s) {
+		Copy(dc, v)
+		return dc.Interface(), nil
+	}
+	elemHasHook := typeHasDeepCopyHook(t.Elem())
 	for i := 0; i < size; i++ {
-		item, err := _anything(v.Index(i).Interface(), ptrs)
+		var item interface{}
+		var err error
+		if elemHasHook {
+			item, err = _anything(v.Index(i).Interface(), ptrs, s)
+		} else {
+			item, err = _anythingNoHookProbe(v.Index(i).Interface(), ptrs, s)
+		}
 		if err != nil {
 			return nil, fmt.Errorf("failed to clone array item at index %v: %v", i, err)
 		}
@@ -267,13 +1035,24 @@ func _array(x interface{}, ptrs map[uintptr]interface{}) (interface{}, error) {
 	return dc.Interface(), nil
 }
 
-func _array_pointer(x, y interface{}, ptrs map[uintptr]interface{}) error {
+func _array_pointer(x, y interface{}, ptrs map[uintptr]interface{}, s *settings) error {
 	v := ValueOf(x).Elem()
 	t := v.Type()
 	size := t.Len()
 	dc := ValueOf(y).Elem()
+	if canFastCopyElems(t.Elem(), s) {
+		Copy(dc, v)
+		return nil
+	}
+	elemHasHook := typeHasDeepCopyHook(t.Elem())
 	for i := 0; i < size; i++ {
-		item, err := _anything(v.Index(i).Interface(), ptrs)
+		var item interface{}
+		var err error
+		if elemHasHook {
+			item, err = _anything(v.Index(i).Interface(), ptrs, s)
+		} else {
+			item, err = _anythingNoHookProbe(v.Index(i).Interface(), ptrs, s)
+		}
 		if err != nil {
 			return fmt.Errorf("failed to clone array item at index %v: %v", i, err)
 		}
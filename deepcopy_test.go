@@ -0,0 +1,668 @@
+package deepcopy
+
+import (
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+	"unsafe"
+)
+
+func TestConfigRegisterOverridesReflection(t *testing.T) {
+	cfg := NewConfig()
+	called := false
+	cfg.Register(reflect.TypeOf(net.IP{}), func(x interface{}) (interface{}, error) {
+		called = true
+		ip := x.(net.IP)
+		out := make(net.IP, len(ip))
+		copy(out, ip)
+		return out, nil
+	})
+
+	type Host struct {
+		IP net.IP
+	}
+	src := Host{IP: net.ParseIP("192.168.1.1")}
+	dcAny, err := cfg.Anything(src)
+	if err != nil {
+		t.Fatalf("Anything: %v", err)
+	}
+	if !called {
+		t.Fatal("expected registered copier to be called")
+	}
+	dc := dcAny.(Host)
+	if !dc.IP.Equal(src.IP) {
+		t.Fatalf("got %v, want %v", dc.IP, src.IP)
+	}
+}
+
+func TestConfigStructPointerCopyUsesRegisteredCopier(t *testing.T) {
+	cfg := NewConfig()
+	calls := 0
+	cfg.Register(reflect.TypeOf(net.IP{}), func(x interface{}) (interface{}, error) {
+		calls++
+		return x, nil
+	})
+
+	type Host struct {
+		IP net.IP
+	}
+	src := &Host{IP: net.ParseIP("10.0.0.1")}
+	dst := &Host{}
+	if err := cfg.StructPointerCopy(src, dst); err != nil {
+		t.Fatalf("StructPointerCopy: %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("expected registered copier to be called once, got %d", calls)
+	}
+}
+
+// typedFoo mimics a controller-tools generated type: its DeepCopy/DeepCopyInto
+// methods are typed (`*typedFoo`, not `interface{}`), which is the shape
+// DeepCopier/DeepCopyInto can't express directly.
+type typedFoo struct {
+	Value   int
+	copied  bool
+	intoHit bool
+}
+
+func (f *typedFoo) DeepCopy() *typedFoo {
+	out := new(typedFoo)
+	out.Value = f.Value
+	out.copied = true
+	return out
+}
+
+func (f *typedFoo) DeepCopyInto(out *typedFoo) {
+	out.Value = f.Value
+	out.intoHit = true
+}
+
+func TestAsDeepCopierDetectsTypedControllerToolsMethod(t *testing.T) {
+	src := &typedFoo{Value: 7}
+	dc, ok := asDeepCopier(*src)
+	if !ok {
+		t.Fatal("expected a typed DeepCopy() *typedFoo method to be detected")
+	}
+	out := dc.DeepCopy().(typedFoo)
+	if out.Value != 7 || !out.copied {
+		t.Fatalf("got %+v", out)
+	}
+}
+
+func TestAsDeepCopyIntoDetectsTypedControllerToolsMethod(t *testing.T) {
+	src := &typedFoo{Value: 9}
+	dci, ok := asDeepCopyInto(src)
+	if !ok {
+		t.Fatal("expected a typed DeepCopyInto(*typedFoo) method to be detected")
+	}
+	dst := &typedFoo{}
+	dci(dst)
+	if dst.Value != 9 || !dst.intoHit {
+		t.Fatalf("got %+v", dst)
+	}
+}
+
+func TestAnythingUsesTypedDeepCopyForPointerField(t *testing.T) {
+	type Holder struct {
+		Foo *typedFoo
+	}
+	src := Holder{Foo: &typedFoo{Value: 3}}
+	dcAny, err := Anything(src)
+	if err != nil {
+		t.Fatalf("Anything: %v", err)
+	}
+	dc := dcAny.(Holder)
+	if dc.Foo == src.Foo {
+		t.Fatal("expected a new pointer")
+	}
+	if dc.Foo.Value != 3 || !dc.Foo.intoHit {
+		t.Fatalf("expected DeepCopyInto to be used, got %+v", dc.Foo)
+	}
+}
+
+// onlyDeepCopy implements only the typed DeepCopy() *T hook, with no
+// DeepCopyInto, so the DeepCopy hook must still be used on its own when x is
+// reached as a pointer: a top-level Anything(ptr) call, a pointer struct field, or a
+// direct StructPointerCopy(ptr, ptr).
+type onlyDeepCopy struct {
+	Value int
+	hit   bool
+}
+
+func (o *onlyDeepCopy) DeepCopy() *onlyDeepCopy {
+	return &onlyDeepCopy{Value: o.Value, hit: true}
+}
+
+func TestAnythingUsesDeepCopyOnlyHookForTopLevelPointer(t *testing.T) {
+	src := &onlyDeepCopy{Value: 5}
+	dcAny, err := Anything(src)
+	if err != nil {
+		t.Fatalf("Anything: %v", err)
+	}
+	dc := dcAny.(*onlyDeepCopy)
+	if dc == src {
+		t.Fatal("expected a new pointer")
+	}
+	if dc.Value != 5 || !dc.hit {
+		t.Fatalf("expected the DeepCopy-only hook to be used, got %+v", dc)
+	}
+}
+
+func TestAnythingUsesDeepCopyOnlyHookForPointerField(t *testing.T) {
+	type Holder struct {
+		Foo *onlyDeepCopy
+	}
+	src := Holder{Foo: &onlyDeepCopy{Value: 3}}
+	dcAny, err := Anything(src)
+	if err != nil {
+		t.Fatalf("Anything: %v", err)
+	}
+	dc := dcAny.(Holder)
+	if dc.Foo == src.Foo {
+		t.Fatal("expected a new pointer")
+	}
+	if dc.Foo.Value != 3 || !dc.Foo.hit {
+		t.Fatalf("expected the DeepCopy-only hook to be used, got %+v", dc.Foo)
+	}
+}
+
+func TestStructPointerCopyUsesDeepCopyOnlyHook(t *testing.T) {
+	src := &onlyDeepCopy{Value: 8}
+	dst := &onlyDeepCopy{}
+	if err := StructPointerCopy(src, dst); err != nil {
+		t.Fatalf("StructPointerCopy: %v", err)
+	}
+	if dst.Value != 8 || !dst.hit {
+		t.Fatalf("expected the DeepCopy-only hook to be used, got %+v", dst)
+	}
+}
+
+func TestConfigLookupMissFallsBackToReflection(t *testing.T) {
+	cfg := NewConfig()
+	type Point struct{ X, Y int }
+	src := Point{X: 1, Y: 2}
+	dcAny, err := cfg.Anything(src)
+	if err != nil {
+		t.Fatalf("Anything: %v", err)
+	}
+	if dcAny.(Point) != src {
+		t.Fatalf("got %v, want %v", dcAny, src)
+	}
+}
+
+func TestAnythingDefaultSkipsUnexportedFields(t *testing.T) {
+	type withUnexported struct {
+		Name   string
+		secret int
+	}
+	src := withUnexported{Name: "a", secret: 42}
+	dcAny, err := Anything(src)
+	if err != nil {
+		t.Fatalf("Anything: %v", err)
+	}
+	dc := dcAny.(withUnexported)
+	if dc.secret != 0 {
+		t.Fatalf("expected unexported field to stay zero, got %v", dc.secret)
+	}
+}
+
+func TestWithCopyUnexportedCopiesUnexportedFields(t *testing.T) {
+	type withUnexported struct {
+		Name   string
+		secret int
+	}
+	src := withUnexported{Name: "a", secret: 42}
+	dcAny, err := AnythingWithOptions(src, WithCopyUnexported())
+	if err != nil {
+		t.Fatalf("AnythingWithOptions: %v", err)
+	}
+	dc := dcAny.(withUnexported)
+	if dc.secret != 42 {
+		t.Fatalf("expected unexported field to be copied, got %v", dc.secret)
+	}
+}
+
+func TestWithDisallowCyclesErrors(t *testing.T) {
+	type node struct {
+		Next *node
+	}
+	a := &node{}
+	a.Next = a
+	if _, err := AnythingWithOptions(a, WithDisallowCycles()); err == nil {
+		t.Fatal("expected an error for a cyclic pointer graph")
+	}
+	if _, err := Anything(a); err != nil {
+		t.Fatalf("expected Anything to tolerate the cycle by default, got %v", err)
+	}
+}
+
+func TestWithTypeBlocklistShallowCopies(t *testing.T) {
+	type mu struct{ locked bool }
+	type withMutex struct {
+		M mu
+	}
+	src := withMutex{M: mu{locked: true}}
+	dcAny, err := AnythingWithOptions(src, WithTypeBlocklist(reflect.TypeOf(mu{})))
+	if err != nil {
+		t.Fatalf("AnythingWithOptions: %v", err)
+	}
+	dc := dcAny.(withMutex)
+	if dc.M != src.M {
+		t.Fatalf("expected blocklisted type to be shallow-copied as-is, got %+v", dc.M)
+	}
+}
+
+// namedID has a primitive underlying kind (int64), which is exactly the
+// shape the plan cache's fast path fast-Sets instead of recursing into
+// _anything -- so it must not bypass a registered Config copier.
+type namedID int64
+
+func TestConfigOverridesPrimitiveFastPathInStructField(t *testing.T) {
+	cfg := NewConfig()
+	called := false
+	cfg.Register(reflect.TypeOf(namedID(0)), func(x interface{}) (interface{}, error) {
+		called = true
+		return x.(namedID) + 1, nil
+	})
+
+	type withID struct {
+		ID namedID
+	}
+	src := withID{ID: 5}
+	dcAny, err := cfg.Anything(src)
+	if err != nil {
+		t.Fatalf("Anything: %v", err)
+	}
+	if !called {
+		t.Fatal("expected the registered copier to be called for a primitive-kind field")
+	}
+	if dcAny.(withID).ID != 6 {
+		t.Fatalf("got %v, want 6", dcAny.(withID).ID)
+	}
+}
+
+func TestConfigOverridesPrimitiveFastPathInSlice(t *testing.T) {
+	cfg := NewConfig()
+	called := false
+	cfg.Register(reflect.TypeOf(namedID(0)), func(x interface{}) (interface{}, error) {
+		called = true
+		return x.(namedID) + 1, nil
+	})
+
+	src := []namedID{1, 2, 3}
+	dcAny, err := cfg.Anything(src)
+	if err != nil {
+		t.Fatalf("Anything: %v", err)
+	}
+	if !called {
+		t.Fatal("expected the registered copier to be called for each slice element")
+	}
+	dc := dcAny.([]namedID)
+	want := []namedID{2, 3, 4}
+	if !reflect.DeepEqual(dc, want) {
+		t.Fatalf("got %v, want %v", dc, want)
+	}
+}
+
+// idWithHook has a primitive underlying kind but implements DeepCopier, so
+// the fast path must defer to the hook instead of a plain Set.
+type idWithHook int
+
+func (i idWithHook) DeepCopy() interface{} {
+	return i + 100
+}
+
+func TestDeepCopierHookOverridesPrimitiveFastPathInStructField(t *testing.T) {
+	type withID struct {
+		ID idWithHook
+	}
+	src := withID{ID: 1}
+	dcAny, err := Anything(src)
+	if err != nil {
+		t.Fatalf("Anything: %v", err)
+	}
+	if dcAny.(withID).ID != 101 {
+		t.Fatalf("got %v, want 101", dcAny.(withID).ID)
+	}
+}
+
+func TestShallowTagAppliesToUnexportedFieldWithoutCopyUnexported(t *testing.T) {
+	type withMutex struct {
+		Name string
+		mu   sync.Mutex `deepcopy:"shallow"`
+	}
+	src := &withMutex{Name: "a"}
+	src.mu.Lock()
+	defer src.mu.Unlock()
+
+	dst := &withMutex{}
+	if err := StructPointerCopy(src, dst); err != nil {
+		t.Fatalf("StructPointerCopy: %v", err)
+	}
+	if dst.Name != "a" {
+		t.Fatalf("got Name=%q, want %q", dst.Name, "a")
+	}
+	// A real recursive copy of sync.Mutex would produce a fresh, unlocked
+	// zero value; the shallow tag instead assigns the raw value, so the
+	// destination should come out already locked just like the source.
+	if dst.mu.TryLock() {
+		dst.mu.Unlock()
+		t.Fatal("expected the shallow-copied mutex to carry over the locked state")
+	}
+}
+
+func TestSkipTagAppliesToUnexportedFieldWithoutCopyUnexported(t *testing.T) {
+	type withSecret struct {
+		Name   string
+		secret int `deepcopy:"-"`
+	}
+	src := &withSecret{Name: "a", secret: 42}
+	dst := &withSecret{}
+	if err := StructPointerCopy(src, dst); err != nil {
+		t.Fatalf("StructPointerCopy: %v", err)
+	}
+	if dst.secret != 0 {
+		t.Fatalf("expected skip-tagged unexported field to stay zero, got %v", dst.secret)
+	}
+}
+
+func TestUntaggedUnexportedFieldStillNeedsCopyUnexported(t *testing.T) {
+	type withSecret struct {
+		Name   string
+		secret int
+	}
+	src := &withSecret{Name: "a", secret: 42}
+	dst := &withSecret{}
+	if err := StructPointerCopy(src, dst); err != nil {
+		t.Fatalf("StructPointerCopy: %v", err)
+	}
+	if dst.secret != 0 {
+		t.Fatalf("expected untagged unexported field to stay zero without WithCopyUnexported, got %v", dst.secret)
+	}
+}
+
+func TestShallowTagAppliesToUnexportedFieldInAnything(t *testing.T) {
+	// handle stands in for a resource like a DB connection or cache handle:
+	// it must be carried over as-is rather than deep copied field by field.
+	type handle struct{ id int }
+	type withHandle struct {
+		Name string
+		h    handle `deepcopy:"shallow"`
+	}
+	src := withHandle{Name: "a", h: handle{id: 7}}
+
+	dcAny, err := Anything(src)
+	if err != nil {
+		t.Fatalf("Anything: %v", err)
+	}
+	dc := dcAny.(withHandle)
+	if dc.Name != "a" {
+		t.Fatalf("got Name=%q, want %q", dc.Name, "a")
+	}
+	if dc.h != src.h {
+		t.Fatalf("expected the shallow-copied handle to carry over as-is, got %+v", dc.h)
+	}
+}
+
+func TestChannelUnsupportedByDefault(t *testing.T) {
+	ch := make(chan int, 1)
+	if _, err := Anything(ch); err == nil {
+		t.Fatal("expected an error copying a channel with no channel strategy configured")
+	}
+}
+
+func TestChannelShallowReusesSameChannel(t *testing.T) {
+	ch := make(chan int, 1)
+	dcAny, err := AnythingWithOptions(ch, WithShallowChannels())
+	if err != nil {
+		t.Fatalf("AnythingWithOptions: %v", err)
+	}
+	dc := dcAny.(chan int)
+	ch <- 1
+	if got := <-dc; got != 1 {
+		t.Fatalf("expected the shallow copy to share the same underlying channel, got %v", got)
+	}
+}
+
+func TestChannelNewEmptyCreatesFreshChannel(t *testing.T) {
+	ch := make(chan int, 2)
+	ch <- 1
+	ch <- 2
+	dcAny, err := AnythingWithOptions(ch, WithChannelStrategy(ChannelNewEmpty))
+	if err != nil {
+		t.Fatalf("AnythingWithOptions: %v", err)
+	}
+	dc := dcAny.(chan int)
+	if cap(dc) != cap(ch) {
+		t.Fatalf("got cap %d, want %d", cap(dc), cap(ch))
+	}
+	if len(dc) != 0 {
+		t.Fatalf("expected a fresh empty channel, got len %d", len(dc))
+	}
+	if len(ch) != 2 {
+		t.Fatalf("expected the source channel to keep its buffered values, got len %d", len(ch))
+	}
+}
+
+func TestChannelDrainAndRefillMovesBufferedValues(t *testing.T) {
+	ch := make(chan int, 2)
+	ch <- 1
+	ch <- 2
+	dcAny, err := AnythingWithOptions(ch, WithChannelStrategy(ChannelDrainAndRefill))
+	if err != nil {
+		t.Fatalf("AnythingWithOptions: %v", err)
+	}
+	dc := dcAny.(chan int)
+	var got []int
+	for i := 0; i < 2; i++ {
+		got = append(got, <-dc)
+	}
+	if !reflect.DeepEqual(got, []int{1, 2}) {
+		t.Fatalf("got %v, want [1 2]", got)
+	}
+}
+
+// TestChannelStrategyOnDirectionalChannel guards against MakeChan's panic on
+// directional channel types: the strategies must build a bidirectional
+// channel internally and convert it back to the field's direction.
+func TestChannelStrategyOnDirectionalChannel(t *testing.T) {
+	type withSendOnly struct {
+		Ch chan<- int
+	}
+	src := withSendOnly{Ch: make(chan int, 1)}
+	dcAny, err := AnythingWithOptions(src, WithChannelStrategy(ChannelNewEmpty))
+	if err != nil {
+		t.Fatalf("AnythingWithOptions: %v", err)
+	}
+	dc := dcAny.(withSendOnly)
+	if dc.Ch == nil {
+		t.Fatal("expected a non-nil directional channel copy")
+	}
+}
+
+func TestFuncUnsupportedByDefault(t *testing.T) {
+	fn := func() {}
+	if _, err := Anything(fn); err == nil {
+		t.Fatal("expected an error copying a func with no func strategy configured")
+	}
+}
+
+func TestFuncShallowReusesSameFunc(t *testing.T) {
+	called := false
+	fn := func() { called = true }
+	dcAny, err := AnythingWithOptions(fn, WithShallowFuncs())
+	if err != nil {
+		t.Fatalf("AnythingWithOptions: %v", err)
+	}
+	dc := dcAny.(func())
+	dc()
+	if !called {
+		t.Fatal("expected the shallow-copied func to be the same underlying func")
+	}
+}
+
+func TestFuncNilOutLeavesNilFunc(t *testing.T) {
+	fn := func() {}
+	dcAny, err := AnythingWithOptions(fn, WithFuncStrategy(FuncNilOut))
+	if err != nil {
+		t.Fatalf("AnythingWithOptions: %v", err)
+	}
+	dc := dcAny.(func())
+	if dc != nil {
+		t.Fatal("expected FuncNilOut to leave the destination func nil")
+	}
+}
+
+func TestUnsafePointerUnsupportedByDefault(t *testing.T) {
+	x := 1
+	p := unsafe.Pointer(&x)
+	if _, err := Anything(p); err == nil {
+		t.Fatal("expected an error copying an unsafe.Pointer with no strategy configured")
+	}
+}
+
+func TestUnsafePointerShallowReusesSamePointer(t *testing.T) {
+	x := 1
+	p := unsafe.Pointer(&x)
+	dcAny, err := AnythingWithOptions(p, WithUnsafePointerStrategy(UnsafePointerShallow))
+	if err != nil {
+		t.Fatalf("AnythingWithOptions: %v", err)
+	}
+	if dcAny.(unsafe.Pointer) != p {
+		t.Fatal("expected the shallow copy to reuse the same unsafe.Pointer")
+	}
+}
+
+func TestUnsafePointerNilOutLeavesNilPointer(t *testing.T) {
+	x := 1
+	p := unsafe.Pointer(&x)
+	dcAny, err := AnythingWithOptions(p, WithUnsafePointerStrategy(UnsafePointerNilOut))
+	if err != nil {
+		t.Fatalf("AnythingWithOptions: %v", err)
+	}
+	if dcAny.(unsafe.Pointer) != nil {
+		t.Fatal("expected UnsafePointerNilOut to leave the destination pointer nil")
+	}
+}
+
+// valueWithHook has a Struct kind but implements the typed DeepCopy hook on
+// a pointer receiver, so reaching it as a plain (non-pointer) struct field
+// or slice element still needs typeHasDeepCopyHook to report true: that's
+// what lets _struct/_struct_pointer and _slice precompute hasHook for
+// struct-kind fields and elements instead of only primitive ones.
+type valueWithHook struct {
+	Value int
+	hit   bool
+}
+
+func (v *valueWithHook) DeepCopy() *valueWithHook {
+	return &valueWithHook{Value: v.Value, hit: true}
+}
+
+func TestAnythingUsesHookForStructKindField(t *testing.T) {
+	type Holder struct {
+		Foo valueWithHook
+	}
+	src := Holder{Foo: valueWithHook{Value: 3}}
+	dcAny, err := Anything(src)
+	if err != nil {
+		t.Fatalf("Anything: %v", err)
+	}
+	dc := dcAny.(Holder)
+	if dc.Foo.Value != 3 || !dc.Foo.hit {
+		t.Fatalf("expected the hook to be used for a struct-kind field, got %+v", dc.Foo)
+	}
+}
+
+func TestAnythingUsesHookForStructKindSliceElement(t *testing.T) {
+	src := []valueWithHook{{Value: 1}, {Value: 2}}
+	dcAny, err := Anything(src)
+	if err != nil {
+		t.Fatalf("Anything: %v", err)
+	}
+	dc := dcAny.([]valueWithHook)
+	for i, v := range dc {
+		if v.Value != src[i].Value || !v.hit {
+			t.Fatalf("expected the hook to be used for slice element %d, got %+v", i, v)
+		}
+	}
+}
+
+// noHookStruct has no hook at all, so the fieldPlan/typeHasDeepCopyHook
+// precomputation should let field and element copies skip the runtime
+// asDeepCopier probe entirely; this only checks the observable behavior
+// still matches a plain field-by-field / element-by-element copy.
+type noHookStruct struct {
+	A int
+	B string
+}
+
+func TestAnythingCopiesStructKindFieldWithoutHook(t *testing.T) {
+	type Holder struct {
+		Foo noHookStruct
+	}
+	src := Holder{Foo: noHookStruct{A: 1, B: "x"}}
+	dcAny, err := Anything(src)
+	if err != nil {
+		t.Fatalf("Anything: %v", err)
+	}
+	dc := dcAny.(Holder)
+	if dc.Foo != src.Foo {
+		t.Fatalf("got %+v, want %+v", dc.Foo, src.Foo)
+	}
+}
+
+func TestWithConfigComposesWithOtherOptions(t *testing.T) {
+	cfg := NewConfig()
+	cfg.Register(reflect.TypeOf(namedID(0)), func(x interface{}) (interface{}, error) {
+		return x.(namedID) + 1, nil
+	})
+
+	type withUnexportedID struct {
+		id namedID
+	}
+	src := withUnexportedID{id: 5}
+	dcAny, err := AnythingWithOptions(src, WithConfig(cfg), WithCopyUnexported())
+	if err != nil {
+		t.Fatalf("AnythingWithOptions: %v", err)
+	}
+	dc := dcAny.(withUnexportedID)
+	if dc.id != 6 {
+		t.Fatalf("got %v, want 6 (registered copier via WithConfig)", dc.id)
+	}
+}
+
+func TestWithConfigWithoutOtherOptionsSkipsUnexportedFields(t *testing.T) {
+	cfg := NewConfig()
+	cfg.Register(reflect.TypeOf(namedID(0)), func(x interface{}) (interface{}, error) {
+		return x.(namedID) + 1, nil
+	})
+
+	type withUnexportedID struct {
+		id namedID
+	}
+	src := withUnexportedID{id: 5}
+	dcAny, err := AnythingWithOptions(src, WithConfig(cfg))
+	if err != nil {
+		t.Fatalf("AnythingWithOptions: %v", err)
+	}
+	dc := dcAny.(withUnexportedID)
+	if dc.id != 0 {
+		t.Fatalf("got %v, want 0 (unexported field left zero-valued without WithCopyUnexported)", dc.id)
+	}
+}
+
+func TestAnythingCopiesStructKindSliceElementsWithoutHook(t *testing.T) {
+	src := []noHookStruct{{A: 1, B: "x"}, {A: 2, B: "y"}}
+	dcAny, err := Anything(src)
+	if err != nil {
+		t.Fatalf("Anything: %v", err)
+	}
+	dc := dcAny.([]noHookStruct)
+	for i := range src {
+		if dc[i] != src[i] {
+			t.Fatalf("got %+v, want %+v", dc[i], src[i])
+		}
+	}
+}